@@ -0,0 +1,221 @@
+package magika
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// ScanJob is a single unit of work for Scanner.ScanBatch.
+type ScanJob struct {
+	// ID identifies the job in the corresponding ScanResult, e.g. a file
+	// path.
+	ID string
+	// R and Size are the content to scan, as accepted by Scanner.Scan.
+	R    io.ReaderAt
+	Size int
+}
+
+// ScanResult is the outcome of scanning a single ScanJob.
+type ScanResult struct {
+	ID          string
+	ContentType ContentType
+	Score       float32
+	Err         error
+}
+
+// BatchOptions configures Scanner.ScanBatch.
+type BatchOptions struct {
+	// Workers is how many goroutines concurrently call Scan. It defaults to
+	// 1 if not positive.
+	Workers int
+	// Ordered, when true, delivers results on the returned channel in the
+	// same order jobs were received, rather than in completion order.
+	Ordered bool
+	// Timeout bounds how long a single job's Scan call may take. Zero means
+	// no timeout.
+	Timeout time.Duration
+}
+
+// ScanBatch scans jobs over opts.Workers goroutines and streams a
+// ScanResult per job on the returned channel, which is closed once jobs is
+// closed and drained or ctx is done. It is safe for concurrent use, since
+// Scan is.
+func (s *Scanner) ScanBatch(ctx context.Context, jobs <-chan ScanJob, opts BatchOptions) <-chan ScanResult {
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = 1
+	}
+	if opts.Ordered {
+		return s.scanBatchOrdered(ctx, jobs, workers, opts.Timeout)
+	}
+	return s.scanBatchUnordered(ctx, jobs, workers, opts.Timeout)
+}
+
+func (s *Scanner) scanBatchUnordered(ctx context.Context, jobs <-chan ScanJob, workers int, timeout time.Duration) <-chan ScanResult {
+	out := make(chan ScanResult)
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case job, ok := <-jobs:
+					if !ok {
+						return
+					}
+					select {
+					case <-ctx.Done():
+						return
+					case out <- s.scanJob(ctx, job, timeout):
+					}
+				}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(out)
+	}()
+	return out
+}
+
+// scanBatchOrdered runs the same worker pool as scanBatchUnordered, but
+// hands each job a dedicated result slot so a single goroutine can forward
+// results in submission order without serializing the scans themselves.
+func (s *Scanner) scanBatchOrdered(ctx context.Context, jobs <-chan ScanJob, workers int, timeout time.Duration) <-chan ScanResult {
+	type future struct {
+		job ScanJob
+		res chan ScanResult
+	}
+	work := make(chan future, workers)
+	order := make(chan future, workers)
+	out := make(chan ScanResult)
+
+	go func() {
+		defer close(work)
+		defer close(order)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case job, ok := <-jobs:
+				if !ok {
+					return
+				}
+				f := future{job: job, res: make(chan ScanResult, 1)}
+				select {
+				case <-ctx.Done():
+					return
+				case order <- f:
+				}
+				select {
+				case <-ctx.Done():
+					return
+				case work <- f:
+				}
+			}
+		}
+	}()
+
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer wg.Done()
+			for f := range work {
+				f.res <- s.scanJob(ctx, f.job, timeout)
+			}
+		}()
+	}
+
+	go func() {
+		defer close(out)
+		for f := range order {
+			select {
+			case <-ctx.Done():
+				return
+			case r := <-f.res:
+				select {
+				case <-ctx.Done():
+					return
+				case out <- r:
+				}
+			}
+		}
+		wg.Wait()
+	}()
+
+	return out
+}
+
+func (s *Scanner) scanJob(ctx context.Context, job ScanJob, timeout time.Duration) ScanResult {
+	if timeout <= 0 {
+		ct, score, err := s.scanScore(job.R, job.Size)
+		return ScanResult{ID: job.ID, ContentType: ct, Score: score, Err: err}
+	}
+	cctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	type scanned struct {
+		ct    ContentType
+		score float32
+		err   error
+	}
+	done := make(chan scanned, 1)
+	go func() {
+		ct, score, err := s.scanScore(job.R, job.Size)
+		done <- scanned{ct, score, err}
+	}()
+	select {
+	case <-cctx.Done():
+		return ScanResult{ID: job.ID, Err: fmt.Errorf("scan %q: %w", job.ID, cctx.Err())}
+	case r := <-done:
+		return ScanResult{ID: job.ID, ContentType: r.ct, Score: r.score, Err: r.err}
+	}
+}
+
+// errReaderAt is an io.ReaderAt that always fails with err, used by
+// ScanFiles to surface a file-open error through the normal ScanResult
+// pipeline instead of special-casing it.
+type errReaderAt struct{ err error }
+
+func (e errReaderAt) ReadAt([]byte, int64) (int, error) { return 0, e.err }
+
+// ScanFiles is a convenience wrapper around ScanBatch for the common case
+// of classifying a list of files on disk. Files are opened eagerly, before
+// any scanning starts, and are all closed once every job has completed.
+func (s *Scanner) ScanFiles(ctx context.Context, paths []string, opts BatchOptions) []ScanResult {
+	jobs := make(chan ScanJob, len(paths))
+	var files []*os.File
+	for _, p := range paths {
+		f, err := os.Open(p)
+		if err != nil {
+			jobs <- ScanJob{ID: p, R: errReaderAt{err: fmt.Errorf("open %q: %w", p, err)}, Size: 1}
+			continue
+		}
+		fi, err := f.Stat()
+		if err != nil {
+			f.Close()
+			jobs <- ScanJob{ID: p, R: errReaderAt{err: fmt.Errorf("stat %q: %w", p, err)}, Size: 1}
+			continue
+		}
+		files = append(files, f)
+		jobs <- ScanJob{ID: p, R: f, Size: int(fi.Size())}
+	}
+	close(jobs)
+
+	var results []ScanResult
+	for r := range s.ScanBatch(ctx, jobs, opts) {
+		results = append(results, r)
+	}
+	for _, f := range files {
+		f.Close()
+	}
+	return results
+}
@@ -0,0 +1,90 @@
+//go:build cgo && onnxruntime
+
+package magika
+
+import (
+	"bytes"
+	"context"
+	"sort"
+	"testing"
+)
+
+func TestScannerScanBatch(t *testing.T) {
+	s := newTestScanner(t)
+	jobs := make(chan ScanJob, 3)
+	jobs <- ScanJob{ID: "a", R: bytes.NewReader([]byte("hello world")), Size: 11}
+	jobs <- ScanJob{ID: "b", R: bytes.NewReader([]byte{}), Size: 0}
+	jobs <- ScanJob{ID: "c", R: bytes.NewReader([]byte{0x80, 0x80, 0x80, 0x80}), Size: 4}
+	close(jobs)
+
+	var got []ScanResult
+	for r := range s.ScanBatch(context.Background(), jobs, BatchOptions{Workers: 2}) {
+		got = append(got, r)
+	}
+	if len(got) != 3 {
+		t.Fatalf("got %d results, want 3", len(got))
+	}
+	want := map[string]string{
+		"a": contentTypeLabelTxt,
+		"b": contentTypeLabelEmpty,
+		"c": contentTypeLabelUnknown,
+	}
+	for _, r := range got {
+		if r.Err != nil {
+			t.Errorf("job %q: unexpected error: %v", r.ID, r.Err)
+			continue
+		}
+		if r.ContentType.Label != want[r.ID] {
+			t.Errorf("job %q: got label %q, want %q", r.ID, r.ContentType.Label, want[r.ID])
+		}
+	}
+}
+
+func TestScannerScanBatchOrdered(t *testing.T) {
+	s := newTestScanner(t)
+	const n = 20
+	jobs := make(chan ScanJob, n)
+	var ids []string
+	for i := 0; i < n; i++ {
+		id := string(rune('a' + i))
+		ids = append(ids, id)
+		jobs <- ScanJob{ID: id, R: bytes.NewReader([]byte("hello world")), Size: 11}
+	}
+	close(jobs)
+
+	var got []string
+	for r := range s.ScanBatch(context.Background(), jobs, BatchOptions{Workers: 8, Ordered: true}) {
+		got = append(got, r.ID)
+	}
+	if !sort.StringsAreSorted(got) {
+		t.Errorf("results not in submission order: %v", got)
+	}
+	for i, id := range ids {
+		if got[i] != id {
+			t.Errorf("result %d: got ID %q, want %q", i, got[i], id)
+		}
+	}
+}
+
+func TestScannerScanFiles(t *testing.T) {
+	s := newTestScanner(t)
+	results := s.ScanFiles(context.Background(), []string{
+		"../../tests_data/basic/python/code.py",
+		"does/not/exist",
+	}, BatchOptions{Workers: 2})
+	if len(results) != 2 {
+		t.Fatalf("got %d results, want 2", len(results))
+	}
+	var gotErr bool
+	for _, r := range results {
+		if r.ID == "does/not/exist" {
+			if r.Err == nil {
+				t.Errorf("expected an error for a nonexistent file")
+			}
+			gotErr = true
+		}
+	}
+	if !gotErr {
+		t.Errorf("missing result for nonexistent file")
+	}
+}
@@ -3,6 +3,7 @@ package magika
 import (
 	"encoding/json"
 	"fmt"
+	"io/fs"
 	"os"
 	"path"
 )
@@ -32,9 +33,16 @@ type Config struct {
 // ReadConfig is a helper that reads and unmarshal a Config, given an assets
 // dir and a model name.
 func ReadConfig(assetsDir, name string) (Config, error) {
+	return ReadConfigFS(os.DirFS(assetsDir), name)
+}
+
+// ReadConfigFS is like ReadConfig, but reads the assets from the given
+// fs.FS instead of a directory on disk. This allows the Magika assets to
+// be embedded in a binary via //go:embed.
+func ReadConfigFS(fsys fs.FS, name string) (Config, error) {
 	var cfg Config
-	p := configPath(assetsDir, name)
-	b, err := os.ReadFile(p)
+	p := configPath(name)
+	b, err := fs.ReadFile(fsys, p)
 	if err != nil {
 		return Config{}, fmt.Errorf("read %q: %w", p, err)
 	}
@@ -44,19 +52,14 @@ func ReadConfig(assetsDir, name string) (Config, error) {
 	return cfg, nil
 }
 
-// contentTypesKBPath returns the content types KB path for the given
-// asset folder.
-func contentTypesKBPath(assetDir string) string {
-	return path.Join(assetDir, contentTypesKBFile)
-}
-
-// configPath returns the model config for the given asset folder and model
-// name.
-func configPath(assetDir, name string) string {
-	return path.Join(assetDir, modelsDir, name, configFile)
+// configPath returns the model config path, relative to an assets fs.FS,
+// for the given model name.
+func configPath(name string) string {
+	return path.Join(modelsDir, name, configFile)
 }
 
-// modelPath returns the Onnx model for the given asset folder and model name.
-func modelPath(assetDir, name string) string {
-	return path.Join(assetDir, modelsDir, name, modelFile)
+// modelPath returns the Onnx model path, relative to an assets fs.FS, for
+// the given model name.
+func modelPath(name string) string {
+	return path.Join(modelsDir, name, modelFile)
 }
@@ -3,6 +3,7 @@ package magika
 import (
 	"encoding/json"
 	"fmt"
+	"io/fs"
 	"os"
 )
 
@@ -10,6 +11,12 @@ const (
 	contentTypeLabelEmpty   = "empty"
 	contentTypeLabelTxt     = "txt"
 	contentTypeLabelUnknown = "unknown"
+
+	contentTypeLabelZip   = "zip"
+	contentTypeLabelTar   = "tar"
+	contentTypeLabelGzip  = "gzip"
+	contentTypeLabelBzip2 = "bzip2"
+	contentTypeLabelXz    = "xz"
 )
 
 // ContentType holds the definition of a content type.
@@ -27,11 +34,16 @@ type ContentType struct {
 // It returns a dictionary that maps a label as defined in the model config
 // target label space to a content type.
 func readContentTypesKB(assetsDir string) (map[string]ContentType, error) {
+	return readContentTypesKBFS(os.DirFS(assetsDir))
+}
+
+// readContentTypesKBFS is like readContentTypesKB, but reads from the
+// given fs.FS instead of a directory on disk.
+func readContentTypesKBFS(fsys fs.FS) (map[string]ContentType, error) {
 	var ckb map[string]ContentType
-	p := contentTypesKBPath(assetsDir)
-	b, err := os.ReadFile(p)
+	b, err := fs.ReadFile(fsys, contentTypesKBFile)
 	if err != nil {
-		return nil, fmt.Errorf("read %q: %w", p, err)
+		return nil, fmt.Errorf("read %q: %w", contentTypesKBFile, err)
 	}
 	if err := json.Unmarshal(b, &ckb); err != nil {
 		return nil, fmt.Errorf("unmarshal: %w", err)
@@ -0,0 +1,219 @@
+package magika
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// RecursiveOptions bounds the work done by Scanner.ScanRecursive, to
+// protect against zip-bomb style archives.
+type RecursiveOptions struct {
+	// MaxDepth is how many levels of nested containers are unpacked. A
+	// depth of 0 scans only the top-level content, without looking inside
+	// it even if it is a container.
+	MaxDepth int
+	// MaxEntries is how many entries may be inspected across the whole
+	// scan.
+	MaxEntries int
+	// MaxTotalBytes is how many decompressed bytes may be read across the
+	// whole scan.
+	MaxTotalBytes int64
+}
+
+// Result is a node of the content type tree produced by
+// Scanner.ScanRecursive.
+type Result struct {
+	// Path is the entry's path within its immediate parent container. It is
+	// empty for the top-level result and for the decompressed content of a
+	// gzip or bzip2 stream.
+	Path string
+	// Size is the entry's decompressed size in bytes.
+	Size int64
+	// ContentType is the entry's inferred content type.
+	ContentType ContentType
+	// Children holds the results of scanning the entries found inside
+	// ContentType, if it is a recognized container format.
+	Children []Result
+}
+
+// errNotAContainer marks a label ScanRecursive does not know how to unpack.
+var errNotAContainer = errors.New("not a container")
+
+// recursiveState tracks the budgets shared across a whole ScanRecursive
+// call, since they apply to the scan as a whole rather than per entry.
+type recursiveState struct {
+	opts    RecursiveOptions
+	entries int
+	bytes   int64
+}
+
+// ScanRecursive scans r like Scan, and additionally unpacks and classifies
+// the entries of any recognized container format (zip, tar, gzip, bzip2),
+// down to opts.MaxDepth levels deep. opts.MaxEntries and opts.MaxTotalBytes
+// bound the work done across the whole call, to protect against zip-bomb
+// style archives.
+//
+// xz is recognized as a content type but is not unpacked: the standard
+// library has no xz decoder, so an xz entry is reported as a leaf Result
+// with no Children.
+func (s *Scanner) ScanRecursive(r io.ReaderAt, size int, opts RecursiveOptions) (Result, error) {
+	st := &recursiveState{opts: opts}
+	return s.scanRecursive(r, size, "", 0, st)
+}
+
+func (s *Scanner) scanRecursive(r io.ReaderAt, size int, path string, depth int, st *recursiveState) (Result, error) {
+	ct, err := s.Scan(r, size)
+	if err != nil {
+		return Result{}, fmt.Errorf("scan %q: %w", path, err)
+	}
+	res := Result{Path: path, Size: int64(size), ContentType: ct}
+	if depth >= st.opts.MaxDepth {
+		return res, nil
+	}
+	children, err := s.scanContainer(ct.Label, r, int64(size), depth, st)
+	if errors.Is(err, errNotAContainer) {
+		return res, nil
+	}
+	if err != nil {
+		return Result{}, fmt.Errorf("scan container %q: %w", path, err)
+	}
+	res.Children = children
+	return res, nil
+}
+
+func (s *Scanner) scanContainer(label string, r io.ReaderAt, size int64, depth int, st *recursiveState) ([]Result, error) {
+	switch label {
+	case contentTypeLabelZip:
+		return s.scanZip(r, size, depth, st)
+	case contentTypeLabelTar:
+		return s.scanTar(io.NewSectionReader(r, 0, size), depth, st)
+	case contentTypeLabelGzip:
+		return s.scanCompressed(io.NewSectionReader(r, 0, size), func(r io.Reader) (io.Reader, error) {
+			return gzip.NewReader(r)
+		}, depth, st)
+	case contentTypeLabelBzip2:
+		return s.scanCompressed(io.NewSectionReader(r, 0, size), func(r io.Reader) (io.Reader, error) {
+			return bzip2.NewReader(r), nil
+		}, depth, st)
+	case contentTypeLabelXz:
+		// The standard library has no xz (LZMA2) decoder, and we avoid
+		// taking on a new dependency just for this, so xz streams are
+		// classified but their content is not unpacked.
+		return nil, errNotAContainer
+	default:
+		return nil, errNotAContainer
+	}
+}
+
+func (s *Scanner) scanZip(r io.ReaderAt, size int64, depth int, st *recursiveState) ([]Result, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("open zip: %w", err)
+	}
+	var results []Result
+	for _, f := range zr.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		if err := st.addEntry(); err != nil {
+			return results, err
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, fmt.Errorf("open %q: %w", f.Name, err)
+		}
+		b, err := st.readAll(rc)
+		rc.Close()
+		if err != nil {
+			return nil, fmt.Errorf("read %q: %w", f.Name, err)
+		}
+		child, err := s.scanRecursive(bytes.NewReader(b), len(b), f.Name, depth+1, st)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, child)
+	}
+	return results, nil
+}
+
+func (s *Scanner) scanTar(r io.Reader, depth int, st *recursiveState) ([]Result, error) {
+	tr := tar.NewReader(r)
+	var results []Result
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("read tar header: %w", err)
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		if err := st.addEntry(); err != nil {
+			return results, err
+		}
+		b, err := st.readAll(tr)
+		if err != nil {
+			return nil, fmt.Errorf("read %q: %w", hdr.Name, err)
+		}
+		child, err := s.scanRecursive(bytes.NewReader(b), len(b), hdr.Name, depth+1, st)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, child)
+	}
+	return results, nil
+}
+
+// scanCompressed unpacks a single-stream compression format (gzip, bzip2)
+// and scans its decompressed content as the sole child.
+func (s *Scanner) scanCompressed(r io.Reader, newReader func(io.Reader) (io.Reader, error), depth int, st *recursiveState) ([]Result, error) {
+	if err := st.addEntry(); err != nil {
+		return nil, err
+	}
+	dr, err := newReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("open: %w", err)
+	}
+	b, err := st.readAll(dr)
+	if err != nil {
+		return nil, fmt.Errorf("read: %w", err)
+	}
+	child, err := s.scanRecursive(bytes.NewReader(b), len(b), "", depth+1, st)
+	if err != nil {
+		return nil, err
+	}
+	return []Result{child}, nil
+}
+
+// addEntry accounts for one more inspected entry, failing once
+// opts.MaxEntries is exceeded.
+func (st *recursiveState) addEntry() error {
+	st.entries++
+	if st.entries > st.opts.MaxEntries {
+		return fmt.Errorf("exceeded max entries (%d)", st.opts.MaxEntries)
+	}
+	return nil
+}
+
+// readAll reads r fully, failing once opts.MaxTotalBytes is exceeded across
+// the whole ScanRecursive call.
+func (st *recursiveState) readAll(r io.Reader) ([]byte, error) {
+	remaining := st.opts.MaxTotalBytes - st.bytes
+	b, err := io.ReadAll(io.LimitReader(r, remaining+1))
+	if err != nil {
+		return nil, err
+	}
+	st.bytes += int64(len(b))
+	if int64(len(b)) > remaining {
+		return nil, fmt.Errorf("exceeded max total bytes (%d)", st.opts.MaxTotalBytes)
+	}
+	return b, nil
+}
@@ -0,0 +1,57 @@
+//go:build cgo && onnxruntime
+
+package magika
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestScannerScanRecursive(t *testing.T) {
+	const zipPath = "../../tests_data/basic/zip/magika_test.zip"
+	b, err := os.ReadFile(zipPath)
+	if err != nil {
+		t.Fatalf("read %s: %v", zipPath, err)
+	}
+	s := newTestScanner(t)
+	opts := RecursiveOptions{
+		MaxDepth:      2,
+		MaxEntries:    100,
+		MaxTotalBytes: 10 << 20,
+	}
+	res, err := s.ScanRecursive(bytes.NewReader(b), len(b), opts)
+	if err != nil {
+		t.Fatalf("scan recursive: %v", err)
+	}
+	if d := cmp.Diff(contentTypeLabelZip, res.ContentType.Label); d != "" {
+		t.Errorf("unexpected top-level content type (-want +got):\n%s", d)
+	}
+	if len(res.Children) == 0 {
+		t.Fatalf("expected at least one entry inside %s", zipPath)
+	}
+	for _, c := range res.Children {
+		if c.ContentType.Label == "" {
+			t.Errorf("entry %q: missing content type", c.Path)
+		}
+	}
+}
+
+func TestScannerScanRecursiveMaxEntries(t *testing.T) {
+	const zipPath = "../../tests_data/basic/zip/magika_test.zip"
+	b, err := os.ReadFile(zipPath)
+	if err != nil {
+		t.Fatalf("read %s: %v", zipPath, err)
+	}
+	s := newTestScanner(t)
+	opts := RecursiveOptions{
+		MaxDepth:      2,
+		MaxEntries:    0,
+		MaxTotalBytes: 10 << 20,
+	}
+	if _, err := s.ScanRecursive(bytes.NewReader(b), len(b), opts); err == nil {
+		t.Fatalf("expected an error when MaxEntries is exceeded")
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"io/fs"
+	"os"
 	"unicode/utf8"
 
 	"github.com/google/magika/go/onnx"
@@ -22,19 +24,31 @@ type Scanner struct {
 // NewScanner returns a scanner based on the model of the given name defined
 // in the given the assets dir.
 func NewScanner(assetsDir, name string) (*Scanner, error) {
-	cfg, err := ReadConfig(assetsDir, name)
+	return NewScannerFS(os.DirFS(assetsDir), name)
+}
+
+// NewScannerFS is like NewScanner, but reads the model assets from the
+// given fs.FS instead of a directory on disk. This makes it possible to
+// ship a self-contained magika binary with its assets baked in via
+// //go:embed.
+func NewScannerFS(fsys fs.FS, name string) (*Scanner, error) {
+	cfg, err := ReadConfigFS(fsys, name)
 	if err != nil {
 		return nil, fmt.Errorf("read config: %w", err)
 	}
-	p := modelPath(assetsDir, name)
-	ob, err := onnx.NewOnnx(p, len(cfg.TargetLabelsSpace))
+	f, err := fsys.Open(modelPath(name))
+	if err != nil {
+		return nil, fmt.Errorf("open model: %w", err)
+	}
+	defer f.Close()
+	ob, err := onnx.NewOnnxFromReader(f, len(cfg.TargetLabelsSpace))
 	if err != nil {
 		return nil, fmt.Errorf("new onnx: %w", err)
 	}
 	if ob == nil {
 		return nil, errors.New("new onnx: nil onnx object")
 	}
-	ckb, err := readContentTypesKB(assetsDir)
+	ckb, err := readContentTypesKBFS(fsys)
 	if err != nil {
 		return nil, fmt.Errorf("read content types KB: %w", err)
 	}
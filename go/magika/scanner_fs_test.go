@@ -0,0 +1,35 @@
+//go:build cgo && onnxruntime
+
+package magika
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestNewScannerFS(t *testing.T) {
+	const (
+		assetsDir = "../../assets"
+		modelName = "standard_v3_3"
+	)
+	s, err := NewScannerFS(os.DirFS(assetsDir), modelName)
+	if err != nil {
+		t.Fatalf("new scanner fs: %v", err)
+	}
+	data := []byte("package main\n\nfunc main() {}\n")
+	ct, err := s.Scan(bytes.NewReader(data), len(data))
+	if err != nil {
+		t.Fatalf("scan: %v", err)
+	}
+	want := newTestScanner(t)
+	wantCt, err := want.Scan(bytes.NewReader(data), len(data))
+	if err != nil {
+		t.Fatalf("scan: %v", err)
+	}
+	if d := cmp.Diff(wantCt, ct); d != "" {
+		t.Errorf("NewScannerFS result mismatch (-want +got):\n%s", d)
+	}
+}
@@ -0,0 +1,76 @@
+package magika
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+)
+
+// streamSpillThreshold is the largest stream, in bytes, that ScanStream and
+// ExtractFeaturesStream hold in memory; a longer stream is written in full
+// to a temporary file instead, to avoid holding arbitrarily large inputs in
+// RAM. It is a var rather than a const so tests can lower it.
+var streamSpillThreshold int64 = 32 << 20 // 32 MiB
+
+// ScanStream scans r and returns the inferred content type. Unlike Scan,
+// it does not require an io.ReaderAt or a known size upfront, which makes
+// it convenient for pipes, HTTP bodies or archive entries of unknown
+// length. Content of at most streamSpillThreshold bytes is buffered in
+// memory; a longer stream is written in full to a temporary file so that
+// Scan's mid/end/offset features can still be read at arbitrary offsets.
+func (s *Scanner) ScanStream(r io.Reader) (ContentType, error) {
+	ra, size, cleanup, err := bufferStream(r)
+	if err != nil {
+		return ContentType{}, fmt.Errorf("buffer stream: %w", err)
+	}
+	defer cleanup()
+	return s.Scan(ra, size)
+}
+
+// ExtractFeaturesStream is like ExtractFeatures, but reads from r instead
+// of requiring an io.ReaderAt and a known size; see ScanStream.
+func ExtractFeaturesStream(cfg Config, r io.Reader) (Features, error) {
+	ra, size, cleanup, err := bufferStream(r)
+	if err != nil {
+		return Features{}, fmt.Errorf("buffer stream: %w", err)
+	}
+	defer cleanup()
+	return ExtractFeatures(cfg, ra, size)
+}
+
+// bufferStream reads r fully into memory, or, once it grows past
+// streamSpillThreshold, into a temporary file, so that it can be scanned
+// through the same io.ReaderAt-based path as Scan. The returned cleanup
+// func must be called once the caller is done with the returned reader.
+func bufferStream(r io.Reader) (io.ReaderAt, int, func(), error) {
+	var buf bytes.Buffer
+	n, err := buf.ReadFrom(io.LimitReader(r, streamSpillThreshold+1))
+	if err != nil {
+		return nil, 0, nil, fmt.Errorf("read: %w", err)
+	}
+	if n <= streamSpillThreshold {
+		return bytes.NewReader(buf.Bytes()), int(n), func() {}, nil
+	}
+
+	// The stream is larger than we are willing to buffer in memory: spill
+	// what we have already read, plus the remainder of r, to a temp file.
+	f, err := os.CreateTemp("", "magika-stream-*")
+	if err != nil {
+		return nil, 0, nil, fmt.Errorf("create temp file: %w", err)
+	}
+	cleanup := func() {
+		f.Close()
+		os.Remove(f.Name())
+	}
+	if _, err := f.Write(buf.Bytes()); err != nil {
+		cleanup()
+		return nil, 0, nil, fmt.Errorf("write temp file: %w", err)
+	}
+	rest, err := io.Copy(f, r)
+	if err != nil {
+		cleanup()
+		return nil, 0, nil, fmt.Errorf("write temp file: %w", err)
+	}
+	return f, int(n + rest), cleanup, nil
+}
@@ -0,0 +1,66 @@
+//go:build cgo && onnxruntime
+
+package magika
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestScannerScanStream(t *testing.T) {
+	s := newTestScanner(t)
+	for _, c := range []struct {
+		name string
+		data []byte
+		want string
+	}{{
+		name: "empty",
+		data: []byte{},
+		want: contentTypeLabelEmpty,
+	}, {
+		name: "small txt",
+		data: []byte("small"),
+		want: contentTypeLabelTxt,
+	}, {
+		name: "small bin",
+		data: []byte{0x80, 0x80, 0x80, 0x80},
+		want: contentTypeLabelUnknown,
+	}} {
+		t.Run(c.name, func(t *testing.T) {
+			want, err := s.Scan(bytes.NewReader(c.data), len(c.data))
+			if err != nil {
+				t.Fatalf("scan: %v", err)
+			}
+			got, err := s.ScanStream(bytes.NewReader(c.data))
+			if err != nil {
+				t.Fatalf("scan stream: %v", err)
+			}
+			if d := cmp.Diff(want, got); d != "" {
+				t.Errorf("ScanStream(%s) mismatch (-want +got):\n%s", c.name, d)
+			}
+		})
+	}
+}
+
+func TestScannerScanStreamSpill(t *testing.T) {
+	old := streamSpillThreshold
+	streamSpillThreshold = 16
+	defer func() { streamSpillThreshold = old }()
+
+	data := []byte(strings.Repeat("package main\n", 100))
+	s := newTestScanner(t)
+	want, err := s.Scan(bytes.NewReader(data), len(data))
+	if err != nil {
+		t.Fatalf("scan: %v", err)
+	}
+	got, err := s.ScanStream(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("scan stream: %v", err)
+	}
+	if d := cmp.Diff(want, got); d != "" {
+		t.Errorf("ScanStream (spilled) mismatch (-want +got):\n%s", d)
+	}
+}
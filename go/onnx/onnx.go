@@ -1,7 +1,33 @@
 package onnx
 
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
 // Onnx represents something that can run inferences on features.
 type Onnx interface {
 	// Run returns the result of the inference on the given features.
 	Run(features []int32) ([]float32, error)
 }
+
+// NewOnnxFromReader returns an Onnx backed by the model read from r, by
+// copying it to a temporary file and loading it through NewOnnx. This lets
+// callers load a model from an fs.FS (e.g. an embed.FS), without every
+// NewOnnx implementation needing its own in-memory loading path.
+func NewOnnxFromReader(r io.Reader, sizeTarget int) (Onnx, error) {
+	f, err := os.CreateTemp("", "magika-model-*.onnx")
+	if err != nil {
+		return nil, fmt.Errorf("create temp file: %w", err)
+	}
+	defer os.Remove(f.Name())
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("write temp file: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return nil, fmt.Errorf("close temp file: %w", err)
+	}
+	return NewOnnx(f.Name(), sizeTarget)
+}
@@ -0,0 +1,44 @@
+//go:build pureonnx
+
+package onnx
+
+import (
+	"fmt"
+	"os"
+)
+
+// NewOnnx returns an onnx that runs inferences using a minimal pure-Go
+// ONNX interpreter instead of the ONNX Runtime C library. It understands
+// the small subset of ops Magika's standard_v* models are built from
+// (Gather, MatMul/Gemm, Conv, Add, Relu, Gelu, LayerNormalization,
+// Softmax, Reshape, Transpose and Concat), which is enough to run
+// inference without cgo or a linked onnxruntime shared library.
+func NewOnnx(modelPath string, sizeTarget int) (Onnx, error) {
+	b, err := os.ReadFile(modelPath)
+	if err != nil {
+		return nil, fmt.Errorf("read model: %w", err)
+	}
+	g, err := parseModel(b)
+	if err != nil {
+		return nil, fmt.Errorf("parse model: %w", err)
+	}
+	return &pureOnnx{graph: g, sizeTarget: sizeTarget}, nil
+}
+
+// pureOnnx implements the Onnx interface by walking a parsed ONNX graph in
+// pure Go.
+type pureOnnx struct {
+	graph      *graph
+	sizeTarget int
+}
+
+func (p *pureOnnx) Run(features []int32) ([]float32, error) {
+	out, err := p.graph.run(features)
+	if err != nil {
+		return nil, fmt.Errorf("run graph: %w", err)
+	}
+	if len(out) != p.sizeTarget {
+		return nil, fmt.Errorf("unexpected output size: got %d, want %d", len(out), p.sizeTarget)
+	}
+	return out, nil
+}
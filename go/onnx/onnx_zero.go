@@ -1,4 +1,4 @@
-//go:build !(cgo && onnxruntime)
+//go:build !(cgo && onnxruntime) && !pureonnx
 
 package onnx
 
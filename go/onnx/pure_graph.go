@@ -0,0 +1,811 @@
+//go:build pureonnx
+
+package onnx
+
+import (
+	"fmt"
+	"math"
+)
+
+// ONNX protobuf field numbers used below, as defined by onnx.proto.
+// Only the fields relevant to Magika's standard_v* models are decoded.
+const (
+	fieldModelGraph = 7 // ModelProto.graph
+
+	fieldGraphNode        = 1  // GraphProto.node
+	fieldGraphInitializer = 5  // GraphProto.initializer
+	fieldGraphInput       = 11 // GraphProto.input
+	fieldGraphOutput      = 12 // GraphProto.output
+
+	fieldNodeInput  = 1 // NodeProto.input
+	fieldNodeOutput = 2 // NodeProto.output
+	fieldNodeOpType = 4 // NodeProto.op_type
+	fieldNodeAttr   = 5 // NodeProto.attribute
+	fieldAttrName   = 1 // AttributeProto.name
+	fieldAttrF      = 2 // AttributeProto.f
+	fieldAttrI      = 3 // AttributeProto.i
+	fieldAttrS      = 4 // AttributeProto.s
+	fieldAttrInts   = 8 // AttributeProto.ints
+
+	fieldTensorDims      = 1 // TensorProto.dims
+	fieldTensorDataType  = 2 // TensorProto.data_type
+	fieldTensorFloatData = 4 // TensorProto.float_data
+	fieldTensorInt64Data = 7 // TensorProto.int64_data
+	fieldTensorName      = 8 // TensorProto.name
+	fieldTensorRawData   = 9 // TensorProto.raw_data
+
+	fieldValueInfoName = 1 // ValueInfoProto.name
+
+	// dataTypeInt64 is TensorProto.DataType's INT64 value. It is the only
+	// non-float data type Magika's graphs need: Reshape's shape input is
+	// always an int64 initializer. Every other tensor is float32.
+	dataTypeInt64 = 7
+)
+
+// tensor is a dense float32 tensor in row-major order.
+type tensor struct {
+	dims []int64
+	data []float32
+}
+
+func (t tensor) size() int {
+	n := 1
+	for _, d := range t.dims {
+		n *= int(d)
+	}
+	return n
+}
+
+// node is a single ONNX graph node: an op applied to named tensors.
+type node struct {
+	opType  string
+	inputs  []string
+	outputs []string
+	attrs   map[string]attrValue
+}
+
+// attrValue holds the subset of AttributeProto payloads the supported ops
+// read: scalar floats, ints and strings, plus repeated ints (e.g.
+// Transpose's perm).
+type attrValue struct {
+	f    float32
+	i    int64
+	s    string
+	ints []int64
+}
+
+// graph is a parsed ONNX graph, ready to be run given the model's input.
+type graph struct {
+	inputName    string
+	outputName   string
+	initializers map[string]tensor
+	nodes        []node
+}
+
+// parseModel decodes the graph held by a serialized ONNX ModelProto.
+func parseModel(b []byte) (*graph, error) {
+	fs, err := decodeFields(b)
+	if err != nil {
+		return nil, fmt.Errorf("decode model: %w", err)
+	}
+	for _, f := range fs {
+		if f.num != fieldModelGraph {
+			continue
+		}
+		return parseGraph(f.buf)
+	}
+	return nil, fmt.Errorf("no graph found in model")
+}
+
+func parseGraph(b []byte) (*graph, error) {
+	fs, err := decodeFields(b)
+	if err != nil {
+		return nil, fmt.Errorf("decode graph: %w", err)
+	}
+	g := &graph{initializers: map[string]tensor{}}
+	for _, f := range fs {
+		switch f.num {
+		case fieldGraphNode:
+			n, err := parseNode(f.buf)
+			if err != nil {
+				return nil, fmt.Errorf("decode node: %w", err)
+			}
+			g.nodes = append(g.nodes, n)
+		case fieldGraphInitializer:
+			name, t, err := parseTensor(f.buf)
+			if err != nil {
+				return nil, fmt.Errorf("decode initializer: %w", err)
+			}
+			g.initializers[name] = t
+		case fieldGraphInput:
+			if g.inputName == "" {
+				g.inputName, err = valueInfoName(f.buf)
+				if err != nil {
+					return nil, fmt.Errorf("decode input: %w", err)
+				}
+			}
+		case fieldGraphOutput:
+			if g.outputName == "" {
+				g.outputName, err = valueInfoName(f.buf)
+				if err != nil {
+					return nil, fmt.Errorf("decode output: %w", err)
+				}
+			}
+		}
+	}
+	return g, nil
+}
+
+func valueInfoName(b []byte) (string, error) {
+	fs, err := decodeFields(b)
+	if err != nil {
+		return "", err
+	}
+	for _, f := range fs {
+		if f.num == fieldValueInfoName {
+			return string(f.buf), nil
+		}
+	}
+	return "", fmt.Errorf("value info has no name")
+}
+
+func parseNode(b []byte) (node, error) {
+	fs, err := decodeFields(b)
+	if err != nil {
+		return node{}, err
+	}
+	n := node{attrs: map[string]attrValue{}}
+	for _, f := range fs {
+		switch f.num {
+		case fieldNodeInput:
+			n.inputs = append(n.inputs, string(f.buf))
+		case fieldNodeOutput:
+			n.outputs = append(n.outputs, string(f.buf))
+		case fieldNodeOpType:
+			n.opType = string(f.buf)
+		case fieldNodeAttr:
+			name, v, err := parseAttr(f.buf)
+			if err != nil {
+				return node{}, fmt.Errorf("decode attribute: %w", err)
+			}
+			n.attrs[name] = v
+		}
+	}
+	return n, nil
+}
+
+func parseAttr(b []byte) (string, attrValue, error) {
+	fs, err := decodeFields(b)
+	if err != nil {
+		return "", attrValue{}, err
+	}
+	var name string
+	var v attrValue
+	for _, f := range fs {
+		switch f.num {
+		case fieldAttrName:
+			name = string(f.buf)
+		case fieldAttrF:
+			v.f = math.Float32frombits(uint32(f.val))
+		case fieldAttrI:
+			v.i = int64(f.val)
+		case fieldAttrS:
+			v.s = string(f.buf)
+		case fieldAttrInts:
+			if f.wire == 2 {
+				ints, err := packedVarints(f.buf)
+				if err != nil {
+					return "", attrValue{}, fmt.Errorf("decode ints: %w", err)
+				}
+				v.ints = append(v.ints, ints...)
+			} else {
+				v.ints = append(v.ints, int64(f.val))
+			}
+		}
+	}
+	return name, v, nil
+}
+
+func parseTensor(b []byte) (string, tensor, error) {
+	fs, err := decodeFields(b)
+	if err != nil {
+		return "", tensor{}, err
+	}
+	var (
+		name      string
+		t         tensor
+		dataType  int64
+		raw       []byte
+		int64Data []int64
+	)
+	for _, f := range fs {
+		switch f.num {
+		case fieldTensorName:
+			name = string(f.buf)
+		case fieldTensorDims:
+			t.dims = append(t.dims, int64(f.val))
+		case fieldTensorDataType:
+			dataType = int64(f.val)
+		case fieldTensorFloatData:
+			if f.wire == 2 {
+				floats, err := packedFloats(f.buf)
+				if err != nil {
+					return "", tensor{}, fmt.Errorf("decode float_data: %w", err)
+				}
+				t.data = append(t.data, floats...)
+			} else {
+				t.data = append(t.data, math.Float32frombits(uint32(f.val)))
+			}
+		case fieldTensorInt64Data:
+			if f.wire == 2 {
+				ints, err := packedVarints(f.buf)
+				if err != nil {
+					return "", tensor{}, fmt.Errorf("decode int64_data: %w", err)
+				}
+				int64Data = append(int64Data, ints...)
+			} else {
+				int64Data = append(int64Data, int64(f.val))
+			}
+		case fieldTensorRawData:
+			raw = f.buf
+		}
+	}
+	switch {
+	case len(t.data) > 0:
+		// float_data was already populated above.
+	case dataType == dataTypeInt64:
+		ints := int64Data
+		if ints == nil && raw != nil {
+			if ints, err = packedInt64(raw); err != nil {
+				return "", tensor{}, fmt.Errorf("decode raw_data: %w", err)
+			}
+		}
+		t.data = make([]float32, len(ints))
+		for i, v := range ints {
+			t.data[i] = float32(v)
+		}
+	case raw != nil:
+		if t.data, err = packedFloats(raw); err != nil {
+			return "", tensor{}, fmt.Errorf("decode raw_data: %w", err)
+		}
+	}
+	return name, t, nil
+}
+
+// run executes the graph on the given input features, returning the
+// contents of the graph's declared output.
+func (g *graph) run(features []int32) ([]float32, error) {
+	values := make(map[string]tensor, len(g.initializers)+len(g.nodes)+1)
+	for name, t := range g.initializers {
+		values[name] = t
+	}
+	in := make([]float32, len(features))
+	for i, v := range features {
+		in[i] = float32(v)
+	}
+	values[g.inputName] = tensor{dims: []int64{1, int64(len(in))}, data: in}
+
+	for _, n := range g.nodes {
+		out, err := evalNode(n, values)
+		if err != nil {
+			return nil, fmt.Errorf("eval %s %q: %w", n.opType, n.outputs, err)
+		}
+		for i, name := range n.outputs {
+			if i < len(out) {
+				values[name] = out[i]
+			}
+		}
+	}
+
+	out, ok := values[g.outputName]
+	if !ok {
+		return nil, fmt.Errorf("output %q not produced", g.outputName)
+	}
+	return out.data, nil
+}
+
+// evalNode runs a single supported op. Magika's standard_v* models only
+// use embedding lookups, 1D convolutions expressed as matrix
+// multiplications, elementwise activations, layer normalization, softmax
+// and a handful of tensor-shuffling ops, so that is all that is
+// implemented here.
+func evalNode(n node, values map[string]tensor) ([]tensor, error) {
+	in := func(i int) (tensor, error) {
+		if i >= len(n.inputs) {
+			return tensor{}, fmt.Errorf("missing input %d", i)
+		}
+		t, ok := values[n.inputs[i]]
+		if !ok {
+			return tensor{}, fmt.Errorf("input %q not yet computed", n.inputs[i])
+		}
+		return t, nil
+	}
+
+	switch n.opType {
+	case "Gather":
+		data, err := in(0)
+		if err != nil {
+			return nil, err
+		}
+		idx, err := in(1)
+		if err != nil {
+			return nil, err
+		}
+		return []tensor{gather(data, idx)}, nil
+	case "MatMul":
+		a, err := in(0)
+		if err != nil {
+			return nil, err
+		}
+		b, err := in(1)
+		if err != nil {
+			return nil, err
+		}
+		out, err := matMul(a, b)
+		if err != nil {
+			return nil, err
+		}
+		return []tensor{out}, nil
+	case "Gemm":
+		a, err := in(0)
+		if err != nil {
+			return nil, err
+		}
+		b, err := in(1)
+		if err != nil {
+			return nil, err
+		}
+		var c tensor
+		if len(n.inputs) > 2 {
+			if c, err = in(2); err != nil {
+				return nil, err
+			}
+		}
+		alpha, beta := float32(1), float32(1)
+		if v, ok := n.attrs["alpha"]; ok {
+			alpha = v.f
+		}
+		if v, ok := n.attrs["beta"]; ok {
+			beta = v.f
+		}
+		out, err := gemm(a, b, c, alpha, beta, n.attrs["transA"].i != 0, n.attrs["transB"].i != 0)
+		if err != nil {
+			return nil, err
+		}
+		return []tensor{out}, nil
+	case "Conv":
+		x, err := in(0)
+		if err != nil {
+			return nil, err
+		}
+		w, err := in(1)
+		if err != nil {
+			return nil, err
+		}
+		out, err := conv1D(x, w, n.attrs)
+		if err != nil {
+			return nil, err
+		}
+		if len(n.inputs) > 2 {
+			bias, err := in(2)
+			if err != nil {
+				return nil, err
+			}
+			outLen := int(out.dims[2])
+			for i := range out.data {
+				out.data[i] += bias.data[i/outLen]
+			}
+		}
+		return []tensor{out}, nil
+	case "Add":
+		a, err := in(0)
+		if err != nil {
+			return nil, err
+		}
+		b, err := in(1)
+		if err != nil {
+			return nil, err
+		}
+		return []tensor{add(a, b)}, nil
+	case "Relu":
+		x, err := in(0)
+		if err != nil {
+			return nil, err
+		}
+		return []tensor{elementwise(x, func(v float32) float32 { return max32(0, v) })}, nil
+	case "Gelu":
+		x, err := in(0)
+		if err != nil {
+			return nil, err
+		}
+		return []tensor{elementwise(x, gelu)}, nil
+	case "LayerNormalization":
+		x, err := in(0)
+		if err != nil {
+			return nil, err
+		}
+		scale, err := in(1)
+		if err != nil {
+			return nil, err
+		}
+		var bias tensor
+		if len(n.inputs) > 2 {
+			if bias, err = in(2); err != nil {
+				return nil, err
+			}
+		}
+		eps := n.attrs["epsilon"].f
+		if eps == 0 {
+			eps = 1e-5
+		}
+		return []tensor{layerNorm(x, scale, bias, eps)}, nil
+	case "Softmax":
+		x, err := in(0)
+		if err != nil {
+			return nil, err
+		}
+		return []tensor{softmax(x)}, nil
+	case "Reshape":
+		x, err := in(0)
+		if err != nil {
+			return nil, err
+		}
+		shape, err := in(1)
+		if err != nil {
+			return nil, err
+		}
+		return []tensor{reshape(x, shape)}, nil
+	case "Transpose":
+		x, err := in(0)
+		if err != nil {
+			return nil, err
+		}
+		return []tensor{transpose(x, n.attrs["perm"].ints)}, nil
+	case "Concat":
+		ts := make([]tensor, len(n.inputs))
+		for i := range n.inputs {
+			t, err := in(i)
+			if err != nil {
+				return nil, err
+			}
+			ts[i] = t
+		}
+		out, err := concat(ts, int(n.attrs["axis"].i))
+		if err != nil {
+			return nil, err
+		}
+		return []tensor{out}, nil
+	default:
+		return nil, fmt.Errorf("unsupported op %q", n.opType)
+	}
+}
+
+func max32(a, b float32) float32 {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+// gelu is the tanh approximation of the Gaussian Error Linear Unit, as
+// used by the exported Magika models.
+func gelu(x float32) float32 {
+	const c = 0.7978845608028654 // sqrt(2/pi)
+	v := float64(x)
+	return float32(0.5 * v * (1 + math.Tanh(c*(v+0.044715*v*v*v))))
+}
+
+func elementwise(x tensor, f func(float32) float32) tensor {
+	out := tensor{dims: x.dims, data: make([]float32, len(x.data))}
+	for i, v := range x.data {
+		out.data[i] = f(v)
+	}
+	return out
+}
+
+// gather looks up rows of data by the indices held in idx, as used for the
+// model's character embedding table.
+func gather(data, idx tensor) tensor {
+	rowSize := 1
+	if len(data.dims) > 1 {
+		for _, d := range data.dims[1:] {
+			rowSize *= int(d)
+		}
+	}
+	out := tensor{
+		dims: append(append([]int64{}, idx.dims...), data.dims[1:]...),
+		data: make([]float32, 0, len(idx.data)*rowSize),
+	}
+	for _, f := range idx.data {
+		row := int(f)
+		out.data = append(out.data, data.data[row*rowSize:(row+1)*rowSize]...)
+	}
+	return out
+}
+
+// matMul multiplies the last two dimensions of a by b, broadcasting over
+// any leading batch dimension of a.
+func matMul(a, b tensor) (tensor, error) {
+	m, k := int(a.dims[len(a.dims)-2]), int(a.dims[len(a.dims)-1])
+	k2, n := int(b.dims[len(b.dims)-2]), int(b.dims[len(b.dims)-1])
+	if k != k2 {
+		return tensor{}, fmt.Errorf("matmul: inner dimensions do not match (%d vs %d)", k, k2)
+	}
+	batch := len(a.data) / (m * k)
+	out := tensor{dims: append(append([]int64{}, a.dims[:len(a.dims)-2]...), int64(m), int64(n)), data: make([]float32, batch*m*n)}
+	for bi := 0; bi < batch; bi++ {
+		ao := bi * m * k
+		oo := bi * m * n
+		for i := 0; i < m; i++ {
+			for j := 0; j < n; j++ {
+				var sum float32
+				for kk := 0; kk < k; kk++ {
+					sum += a.data[ao+i*k+kk] * b.data[kk*n+j]
+				}
+				out.data[oo+i*n+j] = sum
+			}
+		}
+	}
+	return out, nil
+}
+
+// gemm implements ONNX's Gemm op: alpha*A'*B' + beta*C, where A' and B'
+// are a and b optionally transposed, and C is an optional bias broadcast
+// over the output rows.
+func gemm(a, b, c tensor, alpha, beta float32, transA, transB bool) (tensor, error) {
+	if transA {
+		a = transpose(a, nil)
+	}
+	if transB {
+		b = transpose(b, nil)
+	}
+	out, err := matMul(a, b)
+	if err != nil {
+		return tensor{}, fmt.Errorf("gemm: %w", err)
+	}
+	if alpha != 1 {
+		for i := range out.data {
+			out.data[i] *= alpha
+		}
+	}
+	if len(c.data) == 0 {
+		return out, nil
+	}
+	bias := c
+	if beta != 1 {
+		bias = tensor{dims: c.dims, data: make([]float32, len(c.data))}
+		for i, v := range c.data {
+			bias.data[i] = v * beta
+		}
+	}
+	return add(out, bias), nil
+}
+
+// checkConvDefaults rejects Conv attributes outside the stride-1,
+// no-padding, no-dilation, single-group configuration conv1D implements.
+// Silently ignoring e.g. "same" padding would produce a shorter,
+// misaligned output instead of a visible failure.
+func checkConvDefaults(attrs map[string]attrValue) error {
+	if v, ok := attrs["pads"]; ok {
+		for _, p := range v.ints {
+			if p != 0 {
+				return fmt.Errorf("conv: unsupported pads %v, only no padding is supported", v.ints)
+			}
+		}
+	}
+	if v, ok := attrs["strides"]; ok {
+		for _, s := range v.ints {
+			if s != 1 {
+				return fmt.Errorf("conv: unsupported strides %v, only stride 1 is supported", v.ints)
+			}
+		}
+	}
+	if v, ok := attrs["dilations"]; ok {
+		for _, d := range v.ints {
+			if d != 1 {
+				return fmt.Errorf("conv: unsupported dilations %v, only dilation 1 is supported", v.ints)
+			}
+		}
+	}
+	if v, ok := attrs["group"]; ok && v.i != 1 {
+		return fmt.Errorf("conv: unsupported group %d, only group 1 is supported", v.i)
+	}
+	if v, ok := attrs["auto_pad"]; ok && v.s != "" && v.s != "NOTSET" {
+		return fmt.Errorf("conv: unsupported auto_pad %q, only NOTSET is supported", v.s)
+	}
+	return nil
+}
+
+// conv1D implements a 1D convolution with stride 1, no padding, no
+// dilation and a single group, which is what the Magika models use, as a
+// direct sliding-window sum over every output channel and position.
+func conv1D(x, w tensor, attrs map[string]attrValue) (tensor, error) {
+	if err := checkConvDefaults(attrs); err != nil {
+		return tensor{}, err
+	}
+	cOut, cIn, k := int(w.dims[0]), int(w.dims[1]), int(w.dims[2])
+	l := int(x.dims[len(x.dims)-1])
+	outLen := l - k + 1
+	out := tensor{dims: []int64{1, int64(cOut), int64(outLen)}, data: make([]float32, cOut*outLen)}
+	for oc := 0; oc < cOut; oc++ {
+		for t := 0; t < outLen; t++ {
+			var sum float32
+			for ic := 0; ic < cIn; ic++ {
+				for kk := 0; kk < k; kk++ {
+					sum += x.data[ic*l+t+kk] * w.data[oc*cIn*k+ic*k+kk]
+				}
+			}
+			out.data[oc*outLen+t] = sum
+		}
+	}
+	return out, nil
+}
+
+// add adds b to a, broadcasting b over a's leading dimensions when b holds
+// exactly one "row" of a (the common bias-add case).
+func add(a, b tensor) tensor {
+	out := tensor{dims: a.dims, data: make([]float32, len(a.data))}
+	if len(a.data) == len(b.data) {
+		for i := range out.data {
+			out.data[i] = a.data[i] + b.data[i]
+		}
+		return out
+	}
+	for i := range out.data {
+		out.data[i] = a.data[i] + b.data[i%len(b.data)]
+	}
+	return out
+}
+
+// layerNorm normalizes the last dimension of x and applies scale and bias.
+func layerNorm(x, scale, bias tensor, eps float32) tensor {
+	n := int(x.dims[len(x.dims)-1])
+	out := tensor{dims: x.dims, data: make([]float32, len(x.data))}
+	for row := 0; row*n < len(x.data); row++ {
+		seg := x.data[row*n : (row+1)*n]
+		var mean float32
+		for _, v := range seg {
+			mean += v
+		}
+		mean /= float32(n)
+		var variance float32
+		for _, v := range seg {
+			d := v - mean
+			variance += d * d
+		}
+		variance /= float32(n)
+		inv := float32(1 / math.Sqrt(float64(variance)+float64(eps)))
+		for i, v := range seg {
+			norm := (v - mean) * inv * scale.data[i]
+			if len(bias.data) > 0 {
+				norm += bias.data[i]
+			}
+			out.data[row*n+i] = norm
+		}
+	}
+	return out
+}
+
+// softmax normalizes the last dimension of x into a probability
+// distribution.
+func softmax(x tensor) tensor {
+	n := int(x.dims[len(x.dims)-1])
+	out := tensor{dims: x.dims, data: make([]float32, len(x.data))}
+	for row := 0; row*n < len(x.data); row++ {
+		seg := x.data[row*n : (row+1)*n]
+		max := seg[0]
+		for _, v := range seg {
+			max = max32(max, v)
+		}
+		var sum float32
+		res := out.data[row*n : (row+1)*n]
+		for i, v := range seg {
+			e := float32(math.Exp(float64(v - max)))
+			res[i] = e
+			sum += e
+		}
+		for i := range res {
+			res[i] /= sum
+		}
+	}
+	return out
+}
+
+// reshape returns a view of x with a new shape; -1 requests an inferred
+// dimension, as in the ONNX spec.
+func reshape(x, shape tensor) tensor {
+	dims := make([]int64, len(shape.data))
+	known := 1
+	infer := -1
+	for i, v := range shape.data {
+		dims[i] = int64(v)
+		if dims[i] == -1 {
+			infer = i
+		} else {
+			known *= int(dims[i])
+		}
+	}
+	if infer >= 0 {
+		dims[infer] = int64(len(x.data) / known)
+	}
+	return tensor{dims: dims, data: x.data}
+}
+
+// transpose permutes the axes of x according to perm (or reverses them by
+// default).
+func transpose(x tensor, perm []int64) tensor {
+	if len(perm) == 0 {
+		perm = make([]int64, len(x.dims))
+		for i := range perm {
+			perm[i] = int64(len(x.dims) - 1 - i)
+		}
+	}
+	dims := make([]int64, len(perm))
+	for i, p := range perm {
+		dims[i] = x.dims[p]
+	}
+	strides := make([]int, len(x.dims))
+	s := 1
+	for i := len(x.dims) - 1; i >= 0; i-- {
+		strides[i] = s
+		s *= int(x.dims[i])
+	}
+	out := tensor{dims: dims, data: make([]float32, len(x.data))}
+	idx := make([]int, len(dims))
+	for i := range out.data {
+		rem := i
+		for d := 0; d < len(dims); d++ {
+			size := int(dims[d])
+			div := 1
+			for dd := d + 1; dd < len(dims); dd++ {
+				div *= int(dims[dd])
+			}
+			idx[d] = (rem / div) % size
+		}
+		srcOff := 0
+		for d, p := range perm {
+			srcOff += idx[d] * strides[p]
+		}
+		out.data[i] = x.data[srcOff]
+	}
+	return out
+}
+
+// concat joins tensors along axis.
+func concat(ts []tensor, axis int) (tensor, error) {
+	if axis < 0 {
+		axis += len(ts[0].dims)
+	}
+	dims := append([]int64{}, ts[0].dims...)
+	for _, t := range ts[1:] {
+		if len(t.dims) != len(dims) {
+			return tensor{}, fmt.Errorf("concat: rank mismatch (%d vs %d)", len(t.dims), len(dims))
+		}
+		dims[axis] += t.dims[axis]
+	}
+	// A tensor's flat data is laid out as outer*axisSize*inner, where outer
+	// is the product of the dims before axis and inner the product of the
+	// dims after it. Concatenating along axis interleaves each input's
+	// axis-sized chunk into every outer block of the output; flattening and
+	// appending whole buffers in sequence (as if outer were always 1) only
+	// happens to be correct when every leading dim is 1.
+	outer, inner := 1, 1
+	for _, d := range dims[:axis] {
+		outer *= int(d)
+	}
+	for _, d := range dims[axis+1:] {
+		inner *= int(d)
+	}
+	out := tensor{dims: dims, data: make([]float32, outer*int(dims[axis])*inner)}
+	for o := 0; o < outer; o++ {
+		axisOff := 0
+		for _, t := range ts {
+			n := int(t.dims[axis]) * inner
+			src := t.data[o*n : o*n+n]
+			dst := out.data[o*int(dims[axis])*inner+axisOff*inner:]
+			copy(dst, src)
+			axisOff += int(t.dims[axis])
+		}
+	}
+	return out, nil
+}
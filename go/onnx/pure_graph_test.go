@@ -0,0 +1,165 @@
+//go:build pureonnx
+
+package onnx
+
+import (
+	"encoding/binary"
+	"math"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// pbVarint appends v to b as a protobuf varint.
+func pbVarint(b []byte, v uint64) []byte {
+	for v >= 0x80 {
+		b = append(b, byte(v)|0x80)
+		v >>= 7
+	}
+	return append(b, byte(v))
+}
+
+// pbVarintField appends a varint-typed field (wire type 0) to b.
+func pbVarintField(b []byte, num int, v uint64) []byte {
+	b = pbVarint(b, uint64(num<<3))
+	return pbVarint(b, v)
+}
+
+// pbBytesField appends a length-delimited field (wire type 2) to b.
+func pbBytesField(b []byte, num int, payload []byte) []byte {
+	b = pbVarint(b, uint64(num<<3|2))
+	b = pbVarint(b, uint64(len(payload)))
+	return append(b, payload...)
+}
+
+func TestParseTensorInt64RawData(t *testing.T) {
+	var raw []byte
+	for _, v := range []int64{3, 5, -1} {
+		buf := make([]byte, 8)
+		binary.LittleEndian.PutUint64(buf, uint64(v))
+		raw = append(raw, buf...)
+	}
+	var b []byte
+	b = pbBytesField(b, fieldTensorName, []byte("shape"))
+	b = pbVarintField(b, fieldTensorDims, 3)
+	b = pbVarintField(b, fieldTensorDataType, dataTypeInt64)
+	b = pbBytesField(b, fieldTensorRawData, raw)
+
+	name, got, err := parseTensor(b)
+	if err != nil {
+		t.Fatalf("parseTensor: %v", err)
+	}
+	if name != "shape" {
+		t.Errorf("name = %q, want %q", name, "shape")
+	}
+	want := tensor{dims: []int64{3}, data: []float32{3, 5, -1}}
+	if d := cmp.Diff(want, got, cmp.AllowUnexported(tensor{})); d != "" {
+		t.Errorf("parseTensor mismatch (-want +got):\n%s", d)
+	}
+}
+
+func TestParseTensorInt64PackedData(t *testing.T) {
+	var packed []byte
+	for _, v := range []int64{2, 768} {
+		packed = pbVarint(packed, uint64(v))
+	}
+	var b []byte
+	b = pbVarintField(b, fieldTensorDims, 2)
+	b = pbVarintField(b, fieldTensorDataType, dataTypeInt64)
+	b = pbBytesField(b, fieldTensorInt64Data, packed)
+
+	_, got, err := parseTensor(b)
+	if err != nil {
+		t.Fatalf("parseTensor: %v", err)
+	}
+	want := tensor{dims: []int64{2}, data: []float32{2, 768}}
+	if d := cmp.Diff(want, got, cmp.AllowUnexported(tensor{})); d != "" {
+		t.Errorf("parseTensor mismatch (-want +got):\n%s", d)
+	}
+}
+
+func TestParseTensorFloatRawData(t *testing.T) {
+	var raw []byte
+	for _, v := range []float32{1.5, -2.5} {
+		buf := make([]byte, 4)
+		binary.LittleEndian.PutUint32(buf, math.Float32bits(v))
+		raw = append(raw, buf...)
+	}
+	var b []byte
+	b = pbVarintField(b, fieldTensorDims, 2)
+	b = pbBytesField(b, fieldTensorRawData, raw)
+
+	_, got, err := parseTensor(b)
+	if err != nil {
+		t.Fatalf("parseTensor: %v", err)
+	}
+	want := tensor{dims: []int64{2}, data: []float32{1.5, -2.5}}
+	if d := cmp.Diff(want, got, cmp.AllowUnexported(tensor{})); d != "" {
+		t.Errorf("parseTensor mismatch (-want +got):\n%s", d)
+	}
+}
+
+func TestConcat(t *testing.T) {
+	for _, c := range []struct {
+		name string
+		ts   []tensor
+		axis int
+		want tensor
+	}{{
+		name: "leading axis",
+		ts: []tensor{
+			{dims: []int64{1, 3}, data: []float32{1, 2, 3}},
+			{dims: []int64{2, 3}, data: []float32{4, 5, 6, 7, 8, 9}},
+		},
+		axis: 0,
+		want: tensor{dims: []int64{3, 3}, data: []float32{1, 2, 3, 4, 5, 6, 7, 8, 9}},
+	}, {
+		name: "non-leading axis interleaves each outer block",
+		ts: []tensor{
+			{dims: []int64{2, 3}, data: []float32{1, 2, 3, 4, 5, 6}},
+			{dims: []int64{2, 2}, data: []float32{7, 8, 9, 10}},
+		},
+		axis: 1,
+		want: tensor{dims: []int64{2, 5}, data: []float32{1, 2, 3, 7, 8, 4, 5, 6, 9, 10}},
+	}} {
+		t.Run(c.name, func(t *testing.T) {
+			got, err := concat(c.ts, c.axis)
+			if err != nil {
+				t.Fatalf("concat: %v", err)
+			}
+			if d := cmp.Diff(c.want, got, cmp.AllowUnexported(tensor{})); d != "" {
+				t.Errorf("concat mismatch (-want +got):\n%s", d)
+			}
+		})
+	}
+}
+
+func TestConcatRankMismatch(t *testing.T) {
+	_, err := concat([]tensor{
+		{dims: []int64{1, 3}, data: []float32{1, 2, 3}},
+		{dims: []int64{3}, data: []float32{1, 2, 3}},
+	}, 0)
+	if err == nil {
+		t.Fatal("concat: got nil error, want rank mismatch error")
+	}
+}
+
+func TestCheckConvDefaults(t *testing.T) {
+	for _, c := range []struct {
+		name    string
+		attrs   map[string]attrValue
+		wantErr bool
+	}{
+		{name: "no attrs", attrs: map[string]attrValue{}},
+		{name: "default auto_pad", attrs: map[string]attrValue{"auto_pad": {s: "NOTSET"}}},
+		{name: "same_upper auto_pad", attrs: map[string]attrValue{"auto_pad": {s: "SAME_UPPER"}}, wantErr: true},
+		{name: "non-default pads", attrs: map[string]attrValue{"pads": {ints: []int64{1, 1}}}, wantErr: true},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			err := checkConvDefaults(c.attrs)
+			if (err != nil) != c.wantErr {
+				t.Errorf("checkConvDefaults(%v) error = %v, wantErr %v", c.attrs, err, c.wantErr)
+			}
+		})
+	}
+}
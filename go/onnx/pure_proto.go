@@ -0,0 +1,119 @@
+//go:build pureonnx
+
+package onnx
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+)
+
+// pbField holds a single decoded protobuf field, keeping whichever payload
+// is relevant for its wire type. Only the wire types used by the ONNX
+// protobuf schema (varint, 32/64-bit fixed and length-delimited) are
+// supported; that is all Magika's standard_v* models require.
+type pbField struct {
+	num  int
+	wire int
+	val  uint64
+	buf  []byte
+}
+
+// decodeFields splits b into its top-level protobuf fields.
+func decodeFields(b []byte) ([]pbField, error) {
+	var fs []pbField
+	for len(b) > 0 {
+		tag, n := protoVarint(b)
+		if n <= 0 {
+			return nil, fmt.Errorf("bad tag")
+		}
+		b = b[n:]
+		f := pbField{num: int(tag >> 3), wire: int(tag & 7)}
+		switch f.wire {
+		case 0:
+			v, n := protoVarint(b)
+			if n <= 0 {
+				return nil, fmt.Errorf("bad varint")
+			}
+			f.val, b = v, b[n:]
+		case 1:
+			if len(b) < 8 {
+				return nil, fmt.Errorf("short fixed64")
+			}
+			f.val, b = binary.LittleEndian.Uint64(b), b[8:]
+		case 2:
+			l, n := protoVarint(b)
+			if n <= 0 || uint64(len(b)-n) < l {
+				return nil, fmt.Errorf("bad length-delimited field")
+			}
+			b = b[n:]
+			f.buf, b = b[:l], b[l:]
+		case 5:
+			if len(b) < 4 {
+				return nil, fmt.Errorf("short fixed32")
+			}
+			f.val, b = uint64(binary.LittleEndian.Uint32(b)), b[4:]
+		default:
+			return nil, fmt.Errorf("unsupported wire type %d for field %d", f.wire, f.num)
+		}
+		fs = append(fs, f)
+	}
+	return fs, nil
+}
+
+// protoVarint decodes a base-128 varint from the head of b, returning the
+// value and the number of bytes it occupied, or (0, 0) if b does not hold a
+// complete varint.
+func protoVarint(b []byte) (uint64, int) {
+	var v uint64
+	for i := 0; i < len(b) && i < 10; i++ {
+		v |= uint64(b[i]&0x7f) << (7 * i)
+		if b[i]&0x80 == 0 {
+			return v, i + 1
+		}
+	}
+	return 0, 0
+}
+
+// packedFloats decodes a packed repeated float field (wire type 2 holding
+// consecutive little-endian float32 values), as used by TensorProto's
+// float_data.
+func packedFloats(b []byte) ([]float32, error) {
+	if len(b)%4 != 0 {
+		return nil, fmt.Errorf("packed float buffer not a multiple of 4 bytes")
+	}
+	out := make([]float32, len(b)/4)
+	for i := range out {
+		out[i] = math.Float32frombits(binary.LittleEndian.Uint32(b[i*4:]))
+	}
+	return out, nil
+}
+
+// packedInt64 decodes a packed repeated int64 field stored as 8-byte
+// little-endian words, as used by TensorProto's raw_data when data_type is
+// INT64.
+func packedInt64(b []byte) ([]int64, error) {
+	if len(b)%8 != 0 {
+		return nil, fmt.Errorf("packed int64 buffer not a multiple of 8 bytes")
+	}
+	out := make([]int64, len(b)/8)
+	for i := range out {
+		out[i] = int64(binary.LittleEndian.Uint64(b[i*8:]))
+	}
+	return out, nil
+}
+
+// packedVarints decodes a packed repeated varint field, as used by
+// TensorProto's int64_data and AttributeProto's ints.
+func packedVarints(b []byte) ([]int64, error) {
+	var out []int64
+	for len(b) > 0 {
+		v, n := protoVarint(b)
+		if n <= 0 {
+			return nil, fmt.Errorf("bad packed varint")
+		}
+		out = append(out, int64(v))
+		b = b[n:]
+	}
+	return out, nil
+}